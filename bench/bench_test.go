@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bench holds benchmarks comparing the cost of passing a
+// logr.Logger through a context.Context against passing it as a plain
+// argument, mirroring klog's BenchmarkPassingLogger/BenchmarkExtractLogger.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+)
+
+// sinks is the set of LogSink implementations each benchmark below is run
+// against: Discard, which does no work at all and so isolates the overhead
+// of the context/argument plumbing itself, and a funcr sink, which does
+// real formatting work and so shows whether that overhead still matters
+// once it's added in.
+func sinks() map[string]logr.Logger {
+	return map[string]logr.Logger{
+		"Discard": logr.Discard(),
+		"Funcr":   funcr.New(func(prefix, args string) {}, funcr.Options{}),
+	}
+}
+
+// logViaArg exercises a logger that was passed in directly, with no context
+// involved at all.
+func logViaArg(logger logr.Logger) {
+	logger.Info("hello", "key", "value")
+}
+
+// logViaContext exercises a logger that must first be extracted from ctx,
+// as logr.FromContextOrDiscard does on every call.
+func logViaContext(ctx context.Context) {
+	logr.FromContextOrDiscard(ctx).Info("hello", "key", "value")
+}
+
+// BenchmarkLoggerAsArg measures logging when the Logger is passed straight
+// to the callee, with no context.Context involved.
+func BenchmarkLoggerAsArg(b *testing.B) {
+	for name, logger := range sinks() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logViaArg(logger)
+			}
+		})
+	}
+}
+
+// BenchmarkLoggerInContext measures logging when the Logger must be
+// extracted from a context.Context on every call, as code that threads a
+// context instead of a Logger has to do.
+func BenchmarkLoggerInContext(b *testing.B) {
+	for name, logger := range sinks() {
+		b.Run(name, func(b *testing.B) {
+			ctx := logr.NewContext(context.Background(), logger)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logViaContext(ctx)
+			}
+		})
+	}
+}
+
+// BenchmarkExtractLogger isolates the cost of FromContextOrDiscard itself,
+// without the subsequent Info call, to separate extraction overhead from
+// formatting overhead.
+func BenchmarkExtractLogger(b *testing.B) {
+	for name, logger := range sinks() {
+		b.Run(name, func(b *testing.B) {
+			ctx := logr.NewContext(context.Background(), logger)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = logr.FromContextOrDiscard(ctx)
+			}
+		})
+	}
+}
+
+// BenchmarkAppendToContext measures the cost of accumulating context-carried
+// attributes with AppendToContext, and of folding them into a Logger on
+// extraction, at a few chain depths.
+func BenchmarkAppendToContext(b *testing.B) {
+	for name, logger := range sinks() {
+		b.Run(name, func(b *testing.B) {
+			for _, depth := range []int{1, 10, 100} {
+				b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+					base := logr.NewContext(context.Background(), logger)
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						ctx := base
+						for j := 0; j < depth; j++ {
+							ctx = logr.AppendToContext(ctx, "key", j)
+						}
+						_ = logr.FromContextOrDiscard(ctx)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2020 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logr
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// contextState is what logr attaches to a context.Context under contextKey:
+// whatever Logger was last attached via NewContext (if any) plus the head of
+// the AppendToContext/WithGroupToContext node chain (if any). NewContext,
+// AppendToContext and WithGroupToContext all re-attach a fresh contextState
+// under that same key, carrying forward whatever they didn't themselves
+// change, so a later lookup is always a single context.Value call no matter
+// how many of these calls came before it -- unlike layering each node under
+// its own key, which would force FromContext to walk past every one of them
+// to reach the Logger underneath.
+type contextState struct {
+	logger    Logger
+	hasLogger bool
+	head      *contextNode
+
+	// folded memoizes the result of folding head into logger, computed the
+	// first time a Logger is extracted from a ctx carrying this exact
+	// *contextState; repeated extraction from the same ctx hits this cache
+	// instead of re-walking and re-folding the chain.
+	folded atomic.Pointer[Logger]
+}
+
+func contextStateFromContext(ctx context.Context) *contextState {
+	cs, _ := ctx.Value(contextKey{}).(*contextState)
+	return cs
+}
+
+// NewContext returns a new Context, derived from ctx, which carries the
+// provided Logger.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	var head *contextNode
+	if cs := contextStateFromContext(ctx); cs != nil {
+		head = cs.head
+	}
+	return context.WithValue(ctx, contextKey{}, &contextState{logger: logger, hasLogger: true, head: head})
+}
+
+// FromContext returns a Logger from ctx or an error if no Logger is found.
+// Any key/value pairs or groups recorded on ctx via AppendToContext or
+// WithGroupToContext are folded in first, including ones recorded before
+// the Logger was attached (NewContext carries them forward).
+func FromContext(ctx context.Context) (Logger, error) {
+	if cs := contextStateFromContext(ctx); cs != nil && cs.hasLogger {
+		return foldContextState(cs), nil
+	}
+
+	return Logger{}, notFoundError{}
+}
+
+// contextNode is one accumulated WithValues or WithGroup call recorded via
+// AppendToContext or WithGroupToContext. Nodes form an immutable,
+// singly-linked list (newest first), so recording one is an O(1) list
+// append no matter how many nodes already precede it.
+type contextNode struct {
+	parent *contextNode
+	// Exactly one of kv or group is set, depending on which of
+	// AppendToContext/WithGroupToContext created this node.
+	kv    []any
+	group string
+}
+
+// AppendToContext returns a new Context, derived from ctx, which records
+// keysAndValues to be folded into the Logger returned by a later
+// FromContext or FromContextOrDiscard call, as if via Logger.WithValues.
+// Recording is lazy: it's a cheap list append regardless of how many pairs
+// or groups have already been recorded on ctx; the actual WithValues call
+// only happens when a Logger is next extracted.
+func AppendToContext(ctx context.Context, keysAndValues ...any) context.Context {
+	if len(keysAndValues) == 0 {
+		return ctx
+	}
+	node := &contextNode{kv: keysAndValues}
+	next := &contextState{head: node}
+	if prev := contextStateFromContext(ctx); prev != nil {
+		node.parent = prev.head
+		next.logger, next.hasLogger = prev.logger, prev.hasLogger
+	}
+	return context.WithValue(ctx, contextKey{}, next)
+}
+
+// WithGroupToContext returns a new Context, derived from ctx, which records
+// a named group to be folded into the Logger returned by a later
+// FromContext or FromContextOrDiscard call. Folding a group only has an
+// effect on a Logger whose sink understands slog-style groups (see
+// SlogSink); otherwise it's silently dropped.
+func WithGroupToContext(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	node := &contextNode{group: name}
+	next := &contextState{head: node}
+	if prev := contextStateFromContext(ctx); prev != nil {
+		node.parent = prev.head
+		next.logger, next.hasLogger = prev.logger, prev.hasLogger
+	}
+	return context.WithValue(ctx, contextKey{}, next)
+}
+
+// headNodeFromContext returns the head of the AppendToContext/
+// WithGroupToContext node chain recorded on ctx, or nil if none has been.
+// Unlike contextStateFromContext, it doesn't require a Logger to have been
+// attached via NewContext, so it also serves callers (like
+// FoldAttrsFromContext) that only care about the recorded attrs/groups.
+func headNodeFromContext(ctx context.Context) *contextNode {
+	cs := contextStateFromContext(ctx)
+	if cs == nil {
+		return nil
+	}
+	return cs.head
+}
+
+// collectContextNodes flattens the linked list starting at node into a
+// slice, oldest (outermost) first, ready to be folded in order.
+func collectContextNodes(node *contextNode) []*contextNode {
+	if node == nil {
+		return nil
+	}
+	var nodes []*contextNode
+	for n := node; n != nil; n = n.parent {
+		nodes = append(nodes, n)
+	}
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return nodes
+}
+
+// groupContextHook folds a WithGroupToContext group name into a Logger, for
+// sinks that support slog-style named groups. It's nil here and wired up by
+// slogr.go's init, which needs log/slog, so that context.go itself stays
+// buildable without it; on a toolchain that can't build slogr.go, recorded
+// groups are silently dropped.
+var groupContextHook func(logger Logger, name string) Logger
+
+// foldContextState applies whatever AppendToContext/WithGroupToContext
+// nodes are reachable from cs.head onto cs.logger, oldest first. The result
+// is cached on cs, so that extracting repeatedly from the same ctx (and
+// hence the same *contextState) only walks and folds the chain once.
+func foldContextState(cs *contextState) Logger {
+	if cs.head == nil {
+		return cs.logger
+	}
+	if f := cs.folded.Load(); f != nil {
+		return *f
+	}
+
+	logger := cs.logger
+	for _, n := range collectContextNodes(cs.head) {
+		if n.kv != nil {
+			logger = logger.WithValues(n.kv...)
+			continue
+		}
+		if groupContextHook != nil {
+			logger = groupContextHook(logger, n.group)
+		}
+	}
+	cs.folded.Store(&logger)
+	return logger
+}
+
+// notFoundError exists to carry an IsNotFound method.
+type notFoundError struct{}
+
+func (notFoundError) Error() string {
+	return "no logr.Logger was present"
+}
+
+func (notFoundError) IsNotFound() bool {
+	return true
+}
+
+// FromContextOrDiscard returns a Logger from ctx. If no Logger is found, this
+// returns a Logger that discards all log messages. Any key/value pairs or
+// groups recorded on ctx via AppendToContext or WithGroupToContext are
+// folded in first, as for FromContext.
+func FromContextOrDiscard(ctx context.Context) Logger {
+	if cs := contextStateFromContext(ctx); cs != nil && cs.hasLogger {
+		return foldContextState(cs)
+	}
+
+	return Discard()
+}
+
+// IsNotFound reports whether err indicates that no Logger was found by
+// FromContext.
+func IsNotFound(err error) bool {
+	var nfe interface{ IsNotFound() bool }
+	return errors.As(err, &nfe) && nfe.IsNotFound()
+}
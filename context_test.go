@@ -0,0 +1,162 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Copyright 2024 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+)
+
+func TestAppendToContext(t *testing.T) {
+	testCases := []struct {
+		name   string
+		build  func(ctx context.Context) context.Context
+		expect string
+	}{{
+		name:   "no appends",
+		build:  func(ctx context.Context) context.Context { return ctx },
+		expect: `{"logger":"","level":0,"msg":"msg"}`,
+	}, {
+		name: "single append",
+		build: func(ctx context.Context) context.Context {
+			return logr.AppendToContext(ctx, "k", "v")
+		},
+		expect: `{"logger":"","level":0,"msg":"msg","k":"v"}`,
+	}, {
+		name: "multiple appends accumulate in order",
+		build: func(ctx context.Context) context.Context {
+			ctx = logr.AppendToContext(ctx, "a", 1)
+			ctx = logr.AppendToContext(ctx, "b", 2)
+			return ctx
+		},
+		expect: `{"logger":"","level":0,"msg":"msg","a":1,"b":2}`,
+	}, {
+		name: "odd-length call still attaches",
+		build: func(ctx context.Context) context.Context {
+			return logr.AppendToContext(ctx, "k")
+		},
+		expect: `{"logger":"","level":0,"msg":"msg","k":"(MISSING)"}`,
+	}, {
+		name:   "empty call is a no-op",
+		build:  func(ctx context.Context) context.Context { return logr.AppendToContext(ctx) },
+		expect: `{"logger":"","level":0,"msg":"msg"}`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var captured string
+			base := funcr.NewJSON(func(s string) { captured = s }, funcr.Options{})
+			ctx := logr.NewContext(context.Background(), base)
+			ctx = tc.build(ctx)
+
+			got, err := logr.FromContext(ctx)
+			if err != nil {
+				t.Fatalf("FromContext: %v", err)
+			}
+			got.Info("msg")
+			if captured != tc.expect {
+				t.Errorf("\nexpected %q\n     got %q", tc.expect, captured)
+			}
+		})
+	}
+}
+
+func TestWithGroupToContext(t *testing.T) {
+	var captured string
+	base := funcr.NewJSON(func(s string) { captured = s }, funcr.Options{})
+	ctx := logr.NewContext(context.Background(), base)
+	ctx = logr.AppendToContext(ctx, "out", 0)
+	ctx = logr.WithGroupToContext(ctx, "g")
+	ctx = logr.AppendToContext(ctx, "in", 1)
+
+	got, err := logr.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext: %v", err)
+	}
+	got.Info("msg")
+
+	expect := `{"logger":"","level":0,"msg":"msg","out":0,"g":{"in":1}}`
+	if captured != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, captured)
+	}
+}
+
+func TestWithGroupToContextEmptyNameIsNoOp(t *testing.T) {
+	ctx := logr.NewContext(context.Background(), logr.Discard())
+	if got := logr.WithGroupToContext(ctx, ""); got != ctx {
+		t.Errorf("expected WithGroupToContext with an empty name to return ctx unchanged")
+	}
+}
+
+// TestFromContextCachesAcrossRepeatedExtraction exercises the memoized fold
+// in FromContext: the same ctx handed to FromContext repeatedly must keep returning a Logger that folds in the same
+// accumulated attributes, not silently drop or duplicate them once the
+// memoized result kicks in.
+func TestFromContextCachesAcrossRepeatedExtraction(t *testing.T) {
+	var captured string
+	base := funcr.NewJSON(func(s string) { captured = s }, funcr.Options{})
+	ctx := logr.NewContext(context.Background(), base)
+	ctx = logr.AppendToContext(ctx, "k", "v")
+
+	first, err := logr.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext: %v", err)
+	}
+	second, err := logr.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext: %v", err)
+	}
+
+	first.Info("msg")
+	want := captured
+	if expect := `{"logger":"","level":0,"msg":"msg","k":"v"}`; want != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, want)
+	}
+	second.Info("msg")
+	if captured != want {
+		t.Errorf("cached extraction produced a different Logger\nfirst  %q\nsecond %q", want, captured)
+	}
+}
+
+func TestFromContextOrDiscardNoLogger(t *testing.T) {
+	got := logr.FromContextOrDiscard(context.Background())
+	got.Info("msg") // must not panic
+}
+
+func TestFoldAttrsFromContext(t *testing.T) {
+	var captured string
+	sink := funcr.NewJSON(func(s string) { captured = s }, funcr.Options{}).GetSink()
+
+	ctx := context.Background()
+	ctx = logr.AppendToContext(ctx, "out", 0)
+	ctx = logr.WithGroupToContext(ctx, "g")
+	ctx = logr.AppendToContext(ctx, "in", 1)
+
+	folded := logr.FoldAttrsFromContext(ctx, sink)
+	logr.New(folded).Info("msg")
+
+	expect := `{"logger":"","level":0,"msg":"msg","out":0,"g":{"in":1}}`
+	if captured != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, captured)
+	}
+}
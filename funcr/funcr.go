@@ -0,0 +1,812 @@
+/*
+Copyright 2021 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package funcr implements formatting of structured log messages and
+// optionally captures the call site and timestamp.
+//
+// The simplest way to use it is via its implementation of a
+// github.com/go-logr/logr.LogSink with output through an arbitrary
+// "write" function. See New and NewJSON for details.
+package funcr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// New returns a logr.Logger which is implemented by an arbitrary function.
+// The logger writes out with a plain text representation.
+func New(fn func(prefix, args string), opts Options) logr.Logger {
+	return logr.New(newSink(fn, NewFormatterText(opts)))
+}
+
+// NewJSON returns a logr.Logger which is implemented by an arbitrary
+// function. The logger writes out JSON objects, one per line.
+func NewJSON(fn func(obj string), opts Options) logr.Logger {
+	fnWrapper := func(_, obj string) {
+		fn(obj)
+	}
+	return logr.New(newSink(fnWrapper, NewFormatterJSON(opts)))
+}
+
+// NewLogfmt returns a logr.Logger which is implemented by an arbitrary
+// function. The logger writes out logfmt lines, one per line.
+func NewLogfmt(fn func(prefix, args string), opts Options) logr.Logger {
+	return logr.New(newSink(fn, NewFormatterLogfmt(opts)))
+}
+
+// newSink creates a logr.LogSink around a formatter and a write function.
+func newSink(fn func(prefix, args string), formatter Formatter) logr.LogSink {
+	l := &fnlogger{
+		Formatter: formatter,
+		write:     fn,
+	}
+	// For skipping fnlogger.Info/Error.
+	l.Formatter.AddCallDepth(1)
+	return l
+}
+
+// Options carries parameters which influence the way logs are generated.
+type Options struct {
+	// LogCaller tells funcr to add a "caller" key to some or all log lines.
+	LogCaller MessageClass
+
+	// LogCallerFunc tells funcr to use the specified function to get the
+	// file and line, rather than runtime.Caller. This is useful for
+	// wrapping funcr in other logging frameworks.
+	LogCallerFunc func() (file string, line int, ok bool)
+
+	// LogTimestamp tells funcr to add a "ts" key to each log line (except
+	// slog records with a zero time). TimestampFormat only chooses how the
+	// timestamp is rendered; it does not enable it.
+	LogTimestamp bool
+
+	// TimestampFormat tells funcr how to render timestamps when LogTimestamp
+	// is enabled. If not specified, a default format will be used.
+	TimestampFormat string
+
+	// Verbosity tells funcr which V logs to produce. Higher values enable
+	// more logs. Info logs at or below this level will be written, while
+	// logs above it will be discarded.
+	Verbosity int
+
+	// RenderBuiltinsHook allows users to mutate the list of key/value pairs
+	// while a log line is being rendered. The kvList argument follows
+	// logr conventions - each pair of elements is one key/value pair.
+	// The list passed to this function is the list of builtin fields,
+	// such as "ts" (timestamp), "caller", "msg", and "error".
+	RenderBuiltinsHook func(kvList []any) []any
+
+	// RenderValuesHook is the same as RenderBuiltinsHook, except that it is
+	// only called for key/value pairs passed to WithValues or WithAttrs.
+	RenderValuesHook func(kvList []any) []any
+
+	// RenderArgsHook is the same as RenderBuiltinsHook, except that it is
+	// only called for key/value pairs passed directly to Info or Error.
+	RenderArgsHook func(kvList []any) []any
+
+	// TraceContext tells funcr, when acting as a logr.SlogSink, to correlate
+	// each log line with the trace and span active in the context.Context
+	// passed to Handle, adding "trace_id", "span_id" and "trace_flags"
+	// builtin fields. It has no effect on the plain LogSink entry points
+	// (Info/Error), which don't carry a context.
+	//
+	// funcr has no dependency on any tracing package and so no way to
+	// recognize a span on its own: enabling this without also setting
+	// TraceContextExtractor is a no-op, since every real tracing library
+	// (OpenTelemetry included) stashes its span behind a context key
+	// private to that library, unreachable without importing it.
+	TraceContext bool
+
+	// TraceContextExtractor pulls trace/span correlation IDs out of a
+	// context.Context for TraceContext; ok should be false when ctx carries
+	// no span. It's required to get any trace_id/span_id/trace_flags
+	// output at all -- there is no usable default. For example, with
+	// OpenTelemetry:
+	//
+	//	TraceContextExtractor: func(ctx context.Context) (traceID, spanID string, sampled bool, ok bool) {
+	//		sc := trace.SpanContextFromContext(ctx)
+	//		if !sc.IsValid() {
+	//			return "", "", false, false
+	//		}
+	//		return sc.TraceID().String(), sc.SpanID().String(), sc.IsSampled(), true
+	//	}
+	TraceContextExtractor func(ctx context.Context) (traceID, spanID string, sampled bool, ok bool)
+}
+
+// MessageClass indicates which category or categories of messages to
+// consider.
+type MessageClass int
+
+const (
+	// None ignores all message classes.
+	None MessageClass = iota
+	// All considers all message classes.
+	All
+	// Info only considers info messages.
+	Info
+	// Error only considers error messages.
+	Error
+)
+
+// fnlogger implements logr.LogSink (and optionally logr.SlogSink, in
+// slogsink.go) by rendering log lines via a Formatter and handing the
+// result to an arbitrary write function.
+type fnlogger struct {
+	Formatter
+	write func(prefix, args string)
+}
+
+func (l fnlogger) WithName(name string) logr.LogSink {
+	l.Formatter.AddName(name)
+	return &l
+}
+
+func (l fnlogger) WithValues(kvList ...any) logr.LogSink {
+	l.Formatter.AddValues(kvList)
+	return &l
+}
+
+func (l fnlogger) WithCallDepth(depth int) logr.LogSink {
+	l.Formatter.AddCallDepth(depth)
+	return &l
+}
+
+func (l fnlogger) Init(info logr.RuntimeInfo) {
+	l.Formatter.Init(info)
+}
+
+func (l fnlogger) Enabled(level int) bool {
+	return level <= l.Formatter.opts.Verbosity
+}
+
+func (l fnlogger) Info(level int, msg string, kvList ...any) {
+	prefix, args := l.FormatInfo(level, msg, kvList)
+	l.write(prefix, args)
+}
+
+func (l fnlogger) Error(err error, msg string, kvList ...any) {
+	prefix, args := l.FormatError(err, msg, kvList)
+	l.write(prefix, args)
+}
+
+var _ logr.LogSink = &fnlogger{}
+var _ logr.CallDepthLogSink = &fnlogger{}
+
+// outputFormat indicates which rendering an entry should use.
+type outputFormat int
+
+const (
+	outputJSON outputFormat = iota
+	outputText
+	outputLogfmt
+)
+
+// NewFormatterJSON returns a Formatter which renders log lines suitable for
+// parsing as JSON objects, one object per line.
+func NewFormatterJSON(opts Options) Formatter {
+	return newFormatter(opts, outputJSON)
+}
+
+// NewFormatterText returns a Formatter which renders log lines as a series
+// of key=value pairs, loosely formatted for human consumption (keys and
+// string values are always quoted, nested structs/maps are flattened with
+// dotted keys).
+func NewFormatterText(opts Options) Formatter {
+	return newFormatter(opts, outputText)
+}
+
+// NewFormatterLogfmt returns a Formatter which renders log lines as logfmt:
+// space-separated "key=value" pairs with bareword keys. Values are quoted
+// only when they are empty or contain a space, "=", a quote, a backslash,
+// or a control character; numbers, booleans and nil are always bare.
+func NewFormatterLogfmt(opts Options) Formatter {
+	return newFormatter(opts, outputLogfmt)
+}
+
+func newFormatter(opts Options, outfmt outputFormat) Formatter {
+	f := Formatter{
+		outputFormat: outfmt,
+		opts:         &opts,
+	}
+	return f
+}
+
+// groupDef represents one enclosing scope (the root scope or a still-open
+// slog group): the name it was opened with ("" for the root) and the
+// rendered key/value pairs that had accumulated in it (via
+// WithValues/WithAttrs) before a nested group was opened.
+type groupDef struct {
+	name      string
+	valuesStr string
+}
+
+// Formatter is an opaque struct which can be used to implement logr.LogSink
+// and/or logr.SlogSink with output through an arbitrary "write" function.
+// Formatter is not a LogSink itself, it just does the key/value rendering.
+type Formatter struct {
+	outputFormat outputFormat
+	prefix       string
+	valuesStr    string
+	depth        int
+	opts         *Options
+
+	// groupName is the name of the currently open (innermost) group, or ""
+	// if no group has been opened. WithGroup with an empty name leaves it
+	// unchanged, which inlines into the enclosing scope.
+	groupName string
+	// groups holds all enclosing groups, outermost first, each already
+	// rendered up to the point the next nested group was opened.
+	groups []groupDef
+	// groupPath is the dot-joined path of all open group names, used by
+	// the text/logfmt formatters (which don't nest) to prefix keys.
+	groupPath string
+}
+
+// Init configures this Formatter from runtime info, such as the call depth
+// imposed by logr itself.
+func (f *Formatter) Init(info logr.RuntimeInfo) {
+	f.depth += info.CallDepth
+}
+
+// AddCallDepth increases the number of stack-frames to skip when attributing
+// the log line to a file and line.
+func (f *Formatter) AddCallDepth(depth int) {
+	f.depth += depth
+}
+
+// AddName appends the specified name to the Formatter's logger name.
+func (f *Formatter) AddName(name string) {
+	if len(f.prefix) > 0 {
+		f.prefix += "/"
+	}
+	f.prefix += name
+}
+
+// AddValues adds key/value pairs to the Formatter, for use in the current
+// group (or the root scope, if no group is open).
+func (f *Formatter) AddValues(kvList []any) {
+	vals := kvList
+	if hook := f.opts.RenderValuesHook; hook != nil {
+		vals = hook(f.sanitize(vals))
+	} else {
+		vals = f.sanitize(vals)
+	}
+	f.valuesStr = f.join(f.valuesStr, f.flattenGroup(vals))
+}
+
+// AddGroup opens a new nested group with the given name, pushing whatever
+// had accumulated so far onto the group stack. An empty name inlines the
+// group into its parent, matching slog's semantics.
+func (f *Formatter) AddGroup(name string) {
+	if name == "" {
+		return
+	}
+	f.groups = append(f.groups[:len(f.groups):len(f.groups)], groupDef{name: f.groupName, valuesStr: f.valuesStr})
+	f.groupName = name
+	f.valuesStr = ""
+	if f.groupPath == "" {
+		f.groupPath = name
+	} else {
+		f.groupPath += "." + name
+	}
+}
+
+// FormatInfo renders an Info log line, returning a prefix (logger name,
+// only meaningful for the text/logfmt formatters) and the rendered body.
+func (f Formatter) FormatInfo(level int, msg string, kvList []any) (prefix, argsStr string) {
+	return f.formatInfoAt(context.Background(), time.Now(), 0, level, msg, kvList)
+}
+
+// FormatError renders an Error log line, returning a prefix (logger name)
+// and the rendered body.
+func (f Formatter) FormatError(err error, msg string, kvList []any) (prefix, argsStr string) {
+	return f.formatErrorAt(context.Background(), time.Now(), 0, err, msg, kvList)
+}
+
+// formatInfoAt is FormatInfo with an explicit context, timestamp and
+// call-site PC, for callers (like the SlogSink in slogsink.go) that already
+// have all three from a slog.Record/Handle call. A zero pc means "look up
+// the caller live".
+func (f Formatter) formatInfoAt(ctx context.Context, ts time.Time, pc uintptr, level int, msg string, kvList []any) (prefix, argsStr string) {
+	args := f.render(f.builtins(ctx, ts, pc, level, msg, nil), kvList)
+	return f.prefix, f.wrapLine(args)
+}
+
+// formatErrorAt is FormatError with an explicit context, timestamp and
+// call-site PC.
+func (f Formatter) formatErrorAt(ctx context.Context, ts time.Time, pc uintptr, err error, msg string, kvList []any) (prefix, argsStr string) {
+	args := f.render(f.builtins(ctx, ts, pc, -1, msg, err), kvList)
+	return f.prefix, f.wrapLine(args)
+}
+
+// wrapLine wraps a rendered line in the braces of a JSON object; it's a
+// no-op for the text/logfmt formatters, which have no object delimiters.
+func (f Formatter) wrapLine(line string) string {
+	if f.outputFormat != outputJSON {
+		return line
+	}
+	return "{" + line + "}"
+}
+
+// builtins renders the always-present fields ("logger", "caller", "ts",
+// "level"/"msg", "error") as a single pre-joined string, applying
+// RenderBuiltinsHook if set.
+func (f Formatter) builtins(ctx context.Context, ts time.Time, pc uintptr, level int, msg string, err error) string {
+	kv := make([]any, 0, 10)
+	if f.outputFormat == outputJSON {
+		// For text/logfmt output the logger name is returned separately as
+		// a prefix, so callers can place it wherever fits their format.
+		kv = append(kv, "logger", f.prefix)
+	}
+
+	if f.opts.LogCaller == All || (level >= 0 && f.opts.LogCaller == Info) || (level < 0 && f.opts.LogCaller == Error) {
+		if file, line, ok := f.callerFor(pc); ok {
+			kv = append(kv, "caller", callerInfo{File: file, Line: line})
+		}
+	}
+
+	if f.opts.TraceContext && ctx != nil && f.opts.TraceContextExtractor != nil {
+		if traceID, spanID, sampled, ok := f.opts.TraceContextExtractor(ctx); ok {
+			kv = append(kv, "trace_id", traceID, "span_id", spanID, "trace_flags", traceFlags(sampled))
+		}
+	}
+
+	if f.opts.LogTimestamp && !ts.IsZero() {
+		format := f.opts.TimestampFormat
+		if format == "" {
+			format = time.RFC3339Nano
+		}
+		kv = append(kv, "ts", ts.Format(format))
+	}
+
+	if level >= 0 {
+		kv = append(kv, "level", level)
+	}
+
+	kv = append(kv, "msg", msg)
+
+	if level < 0 {
+		var errStr any
+		if err != nil {
+			errStr = err.Error()
+		}
+		kv = append(kv, "error", errStr)
+	}
+
+	if hook := f.opts.RenderBuiltinsHook; hook != nil {
+		kv = hook(kv)
+	}
+	return f.flatten(kv, "", true)
+}
+
+// flattenGroup is flatten using the current group path as key prefix; it's
+// used for anything that lives inside the open group (values and args), as
+// opposed to the always-root-level builtins.
+func (f Formatter) flattenGroup(kvList []any) string {
+	return f.flatten(kvList, f.groupPath, true)
+}
+
+// render combines the already-rendered builtins with the current group
+// stack and the call-site key/value pairs, producing the final line body.
+func (f Formatter) render(builtins string, kvList []any) string {
+	args := f.sanitize(kvList)
+	if hook := f.opts.RenderArgsHook; hook != nil {
+		args = hook(args)
+	}
+	argsStr := f.flattenGroup(args)
+
+	inner := f.join(f.valuesStr, argsStr)
+	name := f.groupName
+	for i := len(f.groups) - 1; i >= 0; i-- {
+		g := f.groups[i]
+		wrapped := f.wrapGroup(name, inner)
+		inner = f.join(g.valuesStr, wrapped)
+		name = g.name
+	}
+	wrapped := f.wrapGroup(name, inner)
+	return f.join(builtins, wrapped)
+}
+
+// wrapGroup wraps inner in a nested object/group named name. An empty name
+// inlines inner (no nesting); empty inner content yields "", dropping the
+// group.
+func (f Formatter) wrapGroup(name, inner string) string {
+	if inner == "" {
+		return ""
+	}
+	if name == "" {
+		return inner
+	}
+	switch f.outputFormat {
+	case outputJSON:
+		return fmt.Sprintf("%s:{%s}", f.quoted(name), inner)
+	default:
+		// Text/logfmt formatters don't nest; group membership is already
+		// baked into each key as a dotted prefix by flatten.
+		return inner
+	}
+}
+
+// join combines two already-rendered fragments with the separator
+// appropriate to the output format (comma for JSON, space otherwise),
+// omitting either side if it's empty.
+func (f Formatter) join(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	if f.outputFormat == outputJSON {
+		return a + "," + b
+	}
+	return a + " " + b
+}
+
+// sanitize pads an odd-length kvList with a "(MISSING)" value, as logr's
+// convention requires key/value pairs.
+func (f Formatter) sanitize(kvList []any) []any {
+	if len(kvList)%2 != 0 {
+		kvList = append(kvList, "(MISSING)")
+	}
+	return kvList
+}
+
+// flatten renders a key/value list into a comma-separated (JSON) or
+// space-separated (text) fragment, recursing into nested structs/maps for
+// text output as dotted keys. flattenValues is false when kvList is itself
+// the already-fully-flattened output of flattenComposite, so its leaves
+// (including ones flattenComposite deliberately left as-is past
+// maxFlattenDepth) aren't run back through flattenComposite a second time,
+// which would let a value that hit the depth guard at one call site recurse
+// further on the next, defeating the guard.
+func (f Formatter) flatten(kvList []any, groupPrefix string, flattenValues bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(kvList); i += 2 {
+		if i > 0 {
+			if f.outputFormat == outputJSON {
+				buf.WriteByte(',')
+			} else {
+				buf.WriteByte(' ')
+			}
+		}
+		k, ok := kvList[i].(string)
+		if !ok {
+			k = fmt.Sprintf("%v", kvList[i])
+		}
+		if groupPrefix != "" && f.outputFormat != outputJSON {
+			k = groupPrefix + "." + k
+		}
+		v := kvList[i+1]
+		if nested, ok := v.(rawGroup); ok {
+			switch f.outputFormat {
+			case outputJSON:
+				buf.WriteString(f.quoted(k))
+				buf.WriteString(":{")
+				buf.WriteString(f.flatten(nested, "", true))
+				buf.WriteByte('}')
+			default:
+				buf.WriteString(f.flatten(nested, k, true))
+			}
+			continue
+		}
+		if flattenValues && f.outputFormat != outputJSON {
+			if nested, ok := f.flattenComposite(v, 0); ok {
+				buf.WriteString(f.flatten(nested, k, false))
+				continue
+			}
+		}
+		switch f.outputFormat {
+		case outputJSON:
+			buf.WriteString(f.quoted(k))
+			buf.WriteByte(':')
+			buf.WriteString(f.renderValueJSON(v))
+		case outputLogfmt:
+			buf.WriteString(k)
+			buf.WriteByte('=')
+			buf.WriteString(f.renderValueLogfmt(v))
+		default:
+			f.renderPairText(&buf, k, v)
+		}
+	}
+	return buf.String()
+}
+
+// rawGroup is the value type used for a slog group's flattened contents
+// once it has been converted to a key/value list. It renders as a nested
+// object for the JSON formatter and as dotted keys for text/logfmt.
+type rawGroup []any
+
+// maxFlattenDepth bounds how many levels of nested struct/map fields
+// flattenComposite will recurse into, so a deeply- or self-referential
+// (via pointers) value can't run it away; past this depth a composite value
+// is rendered as an ordinary (JSON-blob) leaf instead.
+const maxFlattenDepth = 16
+
+// flattenComposite expands v's exported struct fields or map entries into a
+// flat, fully dotted key/value list for the text/logfmt formatters -- e.g. a
+// struct Outer{Inner struct{ X int }} becomes ["Inner.X", 1] -- recursing
+// into nested structs/maps up to maxFlattenDepth levels so the dotting
+// happens all in one pass. A struct field tagged `json:"-"` is omitted and
+// `json:"name"` renames it, matching the JSON formatter's own field names.
+// It returns ok=false when v isn't a struct or map, is a type with its own
+// dedicated rendering (error/time.Time/fmt.Stringer), is a nil pointer, has
+// no fields left to flatten (e.g. all unexported or json:"-"), or the depth
+// limit is reached; the caller renders v as an ordinary leaf value in that
+// case, rather than silently dropping the key.
+func (f Formatter) flattenComposite(v any, depth int) (kvList []any, ok bool) {
+	rv, ok := flattenableValue(f.resolve(v))
+	if !ok || depth >= maxFlattenDepth {
+		return nil, false
+	}
+
+	var fields []any // alternating name, value
+	switch rv.Kind() {
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue // unexported
+			}
+			name, skip := jsonFieldName(sf)
+			if skip {
+				continue
+			}
+			fields = append(fields, name, rv.Field(i).Interface())
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, mk := range keys {
+			fields = append(fields, fmt.Sprint(mk.Interface()), rv.MapIndex(mk).Interface())
+		}
+	}
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	kvList = make([]any, 0, len(fields))
+	for i := 0; i < len(fields); i += 2 {
+		name := fields[i].(string)
+		val := f.resolve(fields[i+1])
+		if nested, ok := f.flattenComposite(val, depth+1); ok {
+			for j := 0; j < len(nested); j += 2 {
+				kvList = append(kvList, name+"."+nested[j].(string), nested[j+1])
+			}
+			continue
+		}
+		kvList = append(kvList, name, val)
+	}
+	return kvList, true
+}
+
+// flattenableValue reports whether v is a struct or map worth recursing
+// into for flattenComposite, dereferencing pointers first. Types with their
+// own dedicated rendering (error/time.Time/fmt.Stringer) and nil pointers
+// are not.
+func flattenableValue(v any) (reflect.Value, bool) {
+	switch v.(type) {
+	case error, time.Time, fmt.Stringer:
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || (rv.Kind() != reflect.Struct && rv.Kind() != reflect.Map) {
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+// jsonFieldName returns the dotted-key name flattenComposite should use for
+// struct field sf, honoring a `json:"..."` tag the same way encoding/json
+// would: an explicit name overrides sf.Name, and "-" skips the field.
+func jsonFieldName(sf reflect.StructField) (name string, skip bool) {
+	tag, ok := sf.Tag.Lookup("json")
+	if !ok {
+		return sf.Name, false
+	}
+	if comma := strings.Index(tag, ","); comma >= 0 {
+		tag = tag[:comma]
+	}
+	if tag == "-" {
+		return "", true
+	}
+	if tag == "" {
+		return sf.Name, false
+	}
+	return tag, false
+}
+
+func (f Formatter) quoted(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// renderValueJSON renders a single value as a JSON fragment.
+func (f Formatter) renderValueJSON(v any) string {
+	v = f.resolve(v)
+	switch val := v.(type) {
+	case error:
+		return f.quoted(val.Error())
+	case time.Time:
+		return f.quoted(val.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return f.quoted(val.String())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return f.quoted(fmt.Sprintf("<encoding error: %v>", err))
+	}
+	return string(b)
+}
+
+// resolve unwraps a logr.Marshaler, if v implements it.
+func (f Formatter) resolve(v any) any {
+	if m, ok := v.(logr.Marshaler); ok {
+		return f.resolve(m.MarshalLog())
+	}
+	return v
+}
+
+// renderPairText renders one key/value pair for the text formatter. The
+// key is always quoted; the value uses the same JSON-ish encoding as the
+// JSON formatter, so strings/errors/timestamps are quoted but numbers,
+// bools and null are bare.
+func (f Formatter) renderPairText(buf *bytes.Buffer, key string, v any) {
+	fmt.Fprintf(buf, "%s=%s", strconv.Quote(key), f.renderValueJSON(v))
+}
+
+// renderValueLogfmt renders a single value using logfmt conventions: errors,
+// times and Stringers become strings (quoted only if they actually need
+// it), numbers/bools/nil are bare, and anything else falls back to its JSON
+// encoding; a JSON string result is decoded and, like the rest, quoted only
+// if it needs it.
+func (f Formatter) renderValueLogfmt(v any) string {
+	v = f.resolve(v)
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case error:
+		return f.logfmtQuoted(val.Error())
+	case time.Time:
+		return f.logfmtQuoted(val.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return f.logfmtQuoted(val.String())
+	case string:
+		return f.logfmtQuoted(val)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return f.logfmtQuoted(fmt.Sprintf("<encoding error: %v>", err))
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		_ = json.Unmarshal(b, &s)
+		return f.logfmtQuoted(s)
+	}
+	// Structs, maps and slices marshal to JSON object/array syntax, which
+	// contains quotes around any string keys or values (and whatever those
+	// strings hold) that make a value ambiguous in logfmt; quote it like any
+	// other value that needs it. Bare numbers and e.g. [1,2,3] come through
+	// untouched, since they never need quoting.
+	return f.logfmtQuoted(string(b))
+}
+
+// logfmtQuoted quotes s, escaping '"' and '\', if it is empty or contains a
+// space, '=', a quote, a backslash, or a control character; otherwise it's
+// returned bare.
+func (f Formatter) logfmtQuoted(s string) string {
+	if !needsLogfmtQuoting(s) {
+		return s
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// needsLogfmtQuoting reports whether s must be quoted to be unambiguous in
+// a logfmt line.
+func needsLogfmtQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' || r == '\\' || r < 0x20 {
+			return true
+		}
+	}
+	return false
+}
+
+type callerInfo struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+func (f Formatter) caller() (string, int, bool) {
+	if f.opts.LogCallerFunc != nil {
+		return f.opts.LogCallerFunc()
+	}
+	// +1 for this frame, +1 for Format{Info,Error}, +1 for Info/Error.
+	_, file, line, ok := runtime.Caller(f.depth + 3)
+	if !ok {
+		return "", 0, false
+	}
+	return filepath.Base(file), line, true
+}
+
+// callerFor resolves caller info from an already-captured program counter
+// (as found on a slog.Record), falling back to walking the live call stack
+// when pc is zero. slog captures its record's PC at the original call site
+// before ever reaching a Handler, so that PC is authoritative and avoids
+// having to reason about the frames slog itself adds on the way here.
+func (f Formatter) callerFor(pc uintptr) (string, int, bool) {
+	if pc == 0 {
+		return f.caller()
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.PC == 0 {
+		return "", 0, false
+	}
+	return filepath.Base(frame.File), frame.Line, true
+}
+
+// traceFlags renders sampled as a W3C-Trace-Context-style two-digit hex
+// flags byte.
+func traceFlags(sampled bool) string {
+	if sampled {
+		return "01"
+	}
+	return "00"
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package funcr
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// capture collects the last line written through it, for use by tests that
+// construct a sink directly rather than going through New/NewJSON.
+type capture struct {
+	log string
+}
+
+func (c *capture) Func(prefix, args string) {
+	if prefix != "" {
+		c.log = prefix + " " + args
+		return
+	}
+	c.log = args
+}
+
+// makeKV is a little readability helper for building a key/value list
+// inline in a test table.
+func makeKV(args ...any) []any {
+	return args
+}
+
+func TestFormatterLogfmt(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []any
+		expect string
+	}{{
+		name:   "primitives",
+		args:   makeKV("int", 1, "float", 1.5, "bool", true, "nil", nil),
+		expect: `level=0 msg=msg int=1 float=1.5 bool=true nil=null`,
+	}, {
+		name:   "plain string",
+		args:   makeKV("str", "ABC"),
+		expect: `level=0 msg=msg str=ABC`,
+	}, {
+		name:   "string needing quotes",
+		args:   makeKV("str", `has space, "quote" and \backslash`),
+		expect: `level=0 msg=msg str="has space, \"quote\" and \\backslash"`,
+	}, {
+		name:   "map value is flattened into dotted keys",
+		args:   makeKV("m", map[string]string{"a": "b c"}),
+		expect: `level=0 msg=msg m.a="b c"`,
+	}, {
+		name:   "slice of numbers needs no quoting",
+		args:   makeKV("s", []int{1, 2, 3}),
+		expect: `level=0 msg=msg s=[1,2,3]`,
+	}, {
+		name:   "struct value is flattened into dotted keys",
+		args:   makeKV("req", struct{ Method, Path string }{Method: "GET", Path: "/healthz"}),
+		expect: `level=0 msg=msg req.Method=GET req.Path=/healthz`,
+	}, {
+		name: "nested struct is flattened all the way down",
+		args: makeKV("req", struct {
+			Method string
+			Header struct{ Host string }
+		}{Method: "GET", Header: struct{ Host string }{Host: "example.com"}}),
+		expect: `level=0 msg=msg req.Method=GET req.Header.Host=example.com`,
+	}, {
+		name: "json tag renames and skips struct fields",
+		args: makeKV("req", struct {
+			Method string `json:"method"`
+			Secret string `json:"-"`
+		}{Method: "GET", Secret: "hunter2"}),
+		expect: `level=0 msg=msg req.method=GET`,
+	}, {
+		name:   "error value is not flattened",
+		args:   makeKV("err", errors.New("boom")),
+		expect: `level=0 msg=msg err=boom`,
+	}, {
+		name:   "empty map keeps its key instead of vanishing",
+		args:   makeKV("m", map[string]string{}, "after", "x"),
+		expect: `level=0 msg=msg m={} after=x`,
+	}, {
+		name:   "struct with only unexported fields keeps its key instead of vanishing",
+		args:   makeKV("s", struct{ unexported int }{unexported: 1}, "after", "x"),
+		expect: `level=0 msg=msg s={} after=x`,
+	}, {
+		name:   "time value is not flattened",
+		args:   makeKV("t", time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)),
+		expect: `level=0 msg=msg t=2023-01-02T03:04:05Z`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			capt := &capture{}
+			logger := newSink(capt.Func, NewFormatterLogfmt(Options{}))
+			logger.Info(0, "msg", tc.args...)
+			if capt.log != tc.expect {
+				t.Errorf("\nexpected %q\n     got %q", tc.expect, capt.log)
+			}
+		})
+	}
+}
+
+// TestFormatterLogfmtFlattenDepthGuard asserts that a value nested deeper
+// than maxFlattenDepth renders as an ordinary (JSON-blob) leaf instead of
+// being flattened, so a self-referential structure reached via pointers
+// can't run flattenComposite away.
+func TestFormatterLogfmtFlattenDepthGuard(t *testing.T) {
+	type node struct {
+		Next *node
+		V    int
+	}
+	var deep *node
+	for i := 0; i < maxFlattenDepth+1; i++ {
+		deep = &node{Next: deep, V: i}
+	}
+
+	capt := &capture{}
+	logger := newSink(capt.Func, NewFormatterLogfmt(Options{}))
+	logger.Info(0, "msg", "n", deep)
+
+	if !bytes.Contains([]byte(capt.log), []byte(`="{\"Next\":null,\"V\":0}"`)) {
+		t.Errorf("expected the node past the depth guard to render as a JSON blob, got %q", capt.log)
+	}
+}
+
+func TestFormatterLogfmtGroup(t *testing.T) {
+	capt := &capture{}
+	formatter := NewFormatterLogfmt(Options{})
+	formatter.AddValues(makeKV("out", 0))
+	formatter.AddGroup("g")
+	formatter.AddValues(makeKV("in", 1))
+	logger := newSink(capt.Func, formatter)
+	logger.Info(0, "msg", "k", "v")
+
+	expect := `level=0 msg=msg out=0 g.in=1 g.k=v`
+	if capt.log != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, capt.log)
+	}
+}
+
+func TestFormatterLogfmtError(t *testing.T) {
+	capt := &capture{}
+	logger := newSink(capt.Func, NewFormatterLogfmt(Options{}))
+	logger.Error(errors.New("boom"), "msg")
+
+	expect := `msg=msg error=boom`
+	if capt.log != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, capt.log)
+	}
+}
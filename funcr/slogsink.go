@@ -0,0 +1,112 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Copyright 2023 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package funcr
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-logr/logr"
+)
+
+var _ logr.SlogSink = &fnlogger{}
+
+// Handle implements logr.SlogSink, rendering a slog.Record natively: groups
+// opened via WithGroup nest as objects (or dotted keys, for text output)
+// and slog.GroupValue attributes on the record itself are flattened the
+// same way, inlining empty-key groups and dropping groups with no attrs.
+// If Options.TraceContext is set, ctx is also consulted for trace/span
+// correlation fields. Key/value pairs or groups recorded on ctx via
+// logr.AppendToContext/logr.WithGroupToContext are folded in as well.
+func (l fnlogger) Handle(ctx context.Context, record slog.Record) error {
+	if sink, ok := logr.FoldAttrsFromContext(ctx, logr.LogSink(&l)).(*fnlogger); ok {
+		l = *sink
+	}
+
+	kvList := make([]any, 0, 2*record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		kvList = attrToKVs(attr, kvList)
+		return true
+	})
+
+	if record.Level >= slog.LevelError {
+		prefix, args := l.formatErrorAt(ctx, record.Time, record.PC, nil, record.Message, kvList)
+		l.write(prefix, args)
+		return nil
+	}
+
+	level := levelFromSlog(record.Level)
+	prefix, args := l.formatInfoAt(ctx, record.Time, record.PC, level, record.Message, kvList)
+	l.write(prefix, args)
+	return nil
+}
+
+func (l fnlogger) WithAttrs(attrs []slog.Attr) logr.SlogSink {
+	kvList := make([]any, 0, 2*len(attrs))
+	for _, attr := range attrs {
+		kvList = attrToKVs(attr, kvList)
+	}
+	l.Formatter.AddValues(kvList)
+	return &l
+}
+
+func (l fnlogger) WithGroup(name string) logr.SlogSink {
+	l.Formatter.AddGroup(name)
+	return &l
+}
+
+// levelFromSlog converts a slog.Level below LevelError into a logr
+// verbosity, clamped at 0 (slog levels at or above LevelError are handled
+// via Handle's error path instead and never reach here).
+func levelFromSlog(level slog.Level) int {
+	result := -level
+	if result < 0 {
+		result = 0
+	}
+	return int(result)
+}
+
+// attrToKVs flattens a single slog.Attr into a logr-style key/value pair,
+// appended to kvList. Group attrs with an empty key are inlined into the
+// caller's scope; groups that resolve to zero attrs are dropped entirely.
+func attrToKVs(attr slog.Attr, kvList []any) []any {
+	if attr.Equal(slog.Attr{}) {
+		return kvList
+	}
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		if len(groupAttrs) == 0 {
+			return kvList
+		}
+		var nested []any
+		for _, ga := range groupAttrs {
+			nested = attrToKVs(ga, nested)
+		}
+		if len(nested) == 0 {
+			return kvList
+		}
+		if attr.Key == "" {
+			return append(kvList, nested...)
+		}
+		return append(kvList, attr.Key, rawGroup(nested))
+	}
+	return append(kvList, attr.Key, attr.Value.Any())
+}
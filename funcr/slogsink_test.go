@@ -20,6 +20,7 @@ limitations under the License.
 package funcr
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"path/filepath"
@@ -108,3 +109,69 @@ func TestSlogSinkWithCaller(t *testing.T) {
 		t.Errorf("\nexpected %q\n     got %q", expect, capt.log)
 	}
 }
+
+// fakeSpanContext is a minimal context.Context standing in for a real
+// tracing library's span-carrying context in tests. funcr never looks at it
+// itself: its span is found only by extractFakeSpan below (via a type
+// assertion on ctx), passed in as TraceContextExtractor, just as a real
+// tracer's span is only reachable through an extractor.
+type fakeSpanContext struct {
+	context.Context
+	traceID string
+	spanID  string
+	sampled bool
+}
+
+func extractFakeSpan(ctx context.Context) (traceID, spanID string, sampled bool, ok bool) {
+	fsc, isFSC := ctx.(fakeSpanContext)
+	if !isFSC {
+		return "", "", false, false
+	}
+	return fsc.traceID, fsc.spanID, fsc.sampled, true
+}
+
+func TestSlogSinkWithTraceContext(t *testing.T) {
+	capt := &capture{}
+	opts := Options{TraceContext: true, TraceContextExtractor: extractFakeSpan}
+	logger := logr.New(newSink(capt.Func, NewFormatterJSON(opts)))
+	slogger := slog.New(logr.ToSlogHandler(logger))
+
+	ctx := fakeSpanContext{Context: context.Background(), traceID: "abc123", spanID: "def456", sampled: true}
+	slogger.InfoContext(ctx, "msg", "int", 1)
+
+	expect := `{"logger":"","trace_id":"abc123","span_id":"def456","trace_flags":"01","level":0,"msg":"msg","int":1}`
+	if capt.log != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, capt.log)
+	}
+}
+
+func TestSlogSinkWithTraceContextNoSpan(t *testing.T) {
+	capt := &capture{}
+	opts := Options{TraceContext: true, TraceContextExtractor: extractFakeSpan}
+	logger := logr.New(newSink(capt.Func, NewFormatterJSON(opts)))
+	slogger := slog.New(logr.ToSlogHandler(logger))
+
+	slogger.Info("msg")
+
+	expect := `{"logger":"","level":0,"msg":"msg"}`
+	if capt.log != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, capt.log)
+	}
+}
+
+// TestSlogSinkWithTraceContextNoExtractor asserts that TraceContext alone,
+// without a TraceContextExtractor, never adds trace fields -- funcr has no
+// usable default, unlike LogCaller/LogTimestamp.
+func TestSlogSinkWithTraceContextNoExtractor(t *testing.T) {
+	capt := &capture{}
+	logger := logr.New(newSink(capt.Func, NewFormatterJSON(Options{TraceContext: true})))
+	slogger := slog.New(logr.ToSlogHandler(logger))
+
+	ctx := fakeSpanContext{Context: context.Background(), traceID: "abc123", spanID: "def456", sampled: true}
+	slogger.InfoContext(ctx, "msg")
+
+	expect := `{"logger":"","level":0,"msg":"msg"}`
+	if capt.log != expect {
+		t.Errorf("\nexpected %q\n     got %q", expect, capt.log)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2019 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logr defines abstract interfaces for logging. Packages can depend on
+// these interfaces and callers can implement logging in whatever way is
+// appropriate.
+//
+// This design derives from a layered approach to logging. Logging APIs are
+// not something to be bolted onto applications at the last minute, and
+// logging intents are not simply thrown away when calling into library
+// code. Rather, every layer of a system needs to be able to log, or not,
+// as the top layer sees fit.
+package logr
+
+// New returns a new Logger instance. This is primarily used by libraries
+// implementing LogSink, rather than end users. Passing a nil sink will create
+// a Logger which discards all log lines.
+func New(sink LogSink) Logger {
+	logger := Logger{}
+	logger.setSink(sink)
+	if sink != nil {
+		sink.Init(runtimeInfo)
+	}
+	return logger
+}
+
+// setSink stores the sink and updates any related fields. It mutates the
+// logger and thus is only safe to use for loggers that are not currently
+// being used concurrently.
+func (l *Logger) setSink(sink LogSink) {
+	l.sink = sink
+}
+
+// GetSink returns the stored sink.
+func (l Logger) GetSink() LogSink {
+	return l.sink
+}
+
+// WithSink returns a copy of the logger with the new sink.
+func (l Logger) WithSink(sink LogSink) Logger {
+	l.setSink(sink)
+	return l
+}
+
+// Logger is an interface to an abstract logging implementation. This is a
+// concrete type for performance reasons, but all the real work is passed on
+// to a LogSink. Implementations of LogSink are expected to be trivially
+// comparable (==) for use as a map key.
+type Logger struct {
+	sink  LogSink
+	level int
+}
+
+// Enabled tests whether this Logger is enabled. For example, commandline
+// flags might be used to set the logging verbosity and disable some info
+// logs.
+func (l Logger) Enabled() bool {
+	// Some implementations of LogSink look at the caller in Enabled (e.g.
+	// to discover the name of the package doing the logging). The sink's
+	// Enabled is called directly from here, with no helper frame between.
+	return l.sink != nil && l.sink.Enabled(l.level)
+}
+
+// Info logs a non-error message with the given key/value pairs as context.
+//
+// The msg argument should be used to add some constant description to the
+// log line. The key/value pairs can then be used to add additional
+// variable information. The key/value pairs must alternate string keys and
+// arbitrary values.
+func (l Logger) Info(msg string, keysAndValues ...any) {
+	if l.sink == nil {
+		return
+	}
+	if l.Enabled() {
+		if withHelper, ok := l.sink.(CallStackHelperLogSink); ok {
+			withHelper.GetCallStackHelper()()
+		}
+		l.sink.Info(l.level, msg, keysAndValues...)
+	}
+}
+
+// Error logs an error, with the given message and key/value pairs as
+// context. It functions similarly to Info, but may have unique behavior,
+// and should be preferred for logging errors (see the package documentations
+// for more information). The log message will always be logged, regardless
+// of verbosity level.
+//
+// The msg field should be used to add context to any underlying error,
+// while the err field should be used to attach the actual error that
+// triggered this log line, if present. The err parameter is optional
+// and nil may be passed instead of an error instance.
+func (l Logger) Error(err error, msg string, keysAndValues ...any) {
+	if l.sink == nil {
+		return
+	}
+	if withHelper, ok := l.sink.(CallStackHelperLogSink); ok {
+		withHelper.GetCallStackHelper()()
+	}
+	l.sink.Error(err, msg, keysAndValues...)
+}
+
+// V returns a new Logger instance for a specific verbosity level, relative to
+// this Logger. In other words, V-levels are additive. A higher verbosity
+// level means a log message is less important. Negative V-levels are treated
+// as 0.
+func (l Logger) V(level int) Logger {
+	if level < 0 {
+		level = 0
+	}
+	l.level += level
+	return l
+}
+
+// GetV returns the verbosity level of the logger.
+func (l Logger) GetV() int {
+	return l.level
+}
+
+// WithValues returns a new Logger instance with additional key/value pairs.
+// See Info for documentation on how key/value pairs work.
+func (l Logger) WithValues(keysAndValues ...any) Logger {
+	if l.sink == nil {
+		return l
+	}
+	l.setSink(l.sink.WithValues(keysAndValues...))
+	return l
+}
+
+// WithName returns a new Logger instance with the specified name element
+// added to the Logger's name. Successive calls with WithName append
+// additional suffixes to the Logger's name. It's strongly recommended
+// that name segments contain only letters, digits, and hyphens (see the
+// package documentation for more information).
+func (l Logger) WithName(name string) Logger {
+	if l.sink == nil {
+		return l
+	}
+	l.setSink(l.sink.WithName(name))
+	return l
+}
+
+// WithCallDepth returns a Logger instance that offsets the call stack by the
+// specified number of frames when logging call site information, if that
+// has been configured by the LogSink implementation. This is useful for
+// users who have helper functions between the "real" call site and the
+// location of the logging call.
+func (l Logger) WithCallDepth(depth int) Logger {
+	if l.sink == nil {
+		return l
+	}
+	if withCallDepth, ok := l.sink.(CallDepthLogSink); ok {
+		l.setSink(withCallDepth.WithCallDepth(depth))
+	}
+	return l
+}
+
+// WithCallStackHelper returns a new Logger instance that skips the
+// direct caller when logging call site information, and a matching
+// helper function. The helper function must be called to mark the
+// caller as internal.
+func (l Logger) WithCallStackHelper() (func(), Logger) {
+	var helper func()
+	if l.sink == nil {
+		return func() {}, l
+	}
+	if withCallDepth, ok := l.sink.(CallDepthLogSink); ok {
+		l.setSink(withCallDepth.WithCallDepth(1))
+	}
+	if withHelper, ok := l.sink.(CallStackHelperLogSink); ok {
+		helper = withHelper.GetCallStackHelper()
+	} else {
+		helper = func() {}
+	}
+	return helper, l
+}
+
+// IsZero returns true if this logger is an uninitialized zero value.
+func (l Logger) IsZero() bool {
+	return l.sink == nil
+}
+
+// contextKey is how we find Loggers in a context.Context.
+type contextKey struct{}
+
+// RuntimeInfo holds runtime information about the logr API that implementations
+// may care about. Implementations of LogSink can use this to adjust their
+// behavior, or expose it to callers.
+type RuntimeInfo struct {
+	// CallDepth is the number of call frames the logr library adds between
+	// the end-user and the LogSink. This is used for callers who are
+	// adding their own frames to the call stack, such as helper functions,
+	// and want to have those reported. This is only used by implementations
+	// of LogSink which treat CallDepth and LogSink.WithCallDepth as
+	// additive.
+	CallDepth int
+}
+
+// runtimeInfo is a static global. It must not be changed at run time.
+var runtimeInfo = RuntimeInfo{
+	CallDepth: 1,
+}
+
+// LogSink represents a logging implementation. End-users will generally not
+// interact with this type.
+type LogSink interface {
+	// Init receives optional information about the logr library for LogSink
+	// implementations that need it.
+	Init(info RuntimeInfo)
+
+	// Enabled tests whether this LogSink is enabled at the specified level.
+	// If it returns false, the logger implementation will still call Info
+	// for a log record, but may finish it up more quickly.
+	Enabled(level int) bool
+
+	// Info logs a non-error message with the given key/value pairs as
+	// context. The level argument is provided for optional logging.
+	Info(level int, msg string, keysAndValues ...any)
+
+	// Error logs an error, with the given message and key/value pairs as
+	// context. See Logger.Error for more details.
+	Error(err error, msg string, keysAndValues ...any)
+
+	// WithValues returns a new LogSink with additional key/value pairs.
+	WithValues(keysAndValues ...any) LogSink
+
+	// WithName returns a new LogSink with the specified name appended.
+	WithName(name string) LogSink
+}
+
+// CallDepthLogSink represents a Logger that knows how to climb the call stack
+// to identify the original call site and can offset the depth by a specified
+// number of frames. This is useful for users who have helper functions
+// between the "real" call site and the location of the logging call.
+type CallDepthLogSink interface {
+	// WithCallDepth returns a LogSink that will offset the depth of call
+	// stack by the specified depth before logging call site information.
+	WithCallDepth(depth int) LogSink
+}
+
+// CallStackHelperLogSink represents a Logger that knows how to climb
+// the call stack to identify the original call site and can skip
+// intermediate helper functions if they mark themselves as
+// helper. Go's testing package uses that approach.
+type CallStackHelperLogSink interface {
+	// GetCallStackHelper returns a function that must be called to mark the
+	// direct caller as helper function when logging call site information.
+	GetCallStackHelper() func()
+}
+
+// Marshaler is an optional interface that logged values may implement. Sinks
+// should look for this interface and use it to render log values, instead of
+// the value's natural representation.
+type Marshaler interface {
+	// MarshalLog can be used to:
+	//   - ensure that structs are not logged field-by-field if the handler
+	//     otherwise logs all fields of a struct
+	//   - select which fields of a complex type should get logged
+	//   - do a cheap-to-expensive conversion of internal types to a value
+	//     that can be rendered (e.g. network address to string)
+	MarshalLog() any
+}
@@ -0,0 +1,202 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Copyright 2023 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// ToSlogHandler returns a slog.Handler which writes to the same sink as
+// logger.
+//
+// The returned handler implements Handle, WithAttrs and WithGroup directly
+// and thus can be used as a slog.Handler without a wrapper. If logger's sink
+// is itself a SlogSink, that sink is used directly, preserving native slog
+// behavior (like groups) end-to-end. Otherwise, log records are converted
+// to the logr key/value calling convention; slog groups are represented as
+// a "." separated key prefix in that case, since a plain LogSink has no
+// other way to express nesting.
+func ToSlogHandler(logger Logger) slog.Handler {
+	handler := &slogHandler{sink: logger.GetSink(), levelBias: slog.Level(logger.GetV())}
+
+	if sink, ok := logger.GetSink().(*slogSink); ok {
+		if logger.GetV() == 0 {
+			return sink.handler
+		}
+		handler.sink = sink
+	}
+
+	return handler
+}
+
+// FromSlogHandler returns a Logger which writes to the same sink as handler.
+//
+// The logic inverts ToSlogHandler. If handler is already a slogHandler
+// created by this package, the original sink and verbosity are recovered
+// instead of adding another layer of wrapping.
+func FromSlogHandler(handler slog.Handler) Logger {
+	if handler, ok := handler.(*slogHandler); ok {
+		if handler.groupPrefix == "" {
+			return New(handler.sink).V(int(handler.levelBias))
+		}
+		return New(handler.sink).V(int(handler.levelBias)).WithName(handler.groupPrefix)
+	}
+	return New(&slogSink{handler: handler})
+}
+
+// FromSlogHandlerWithContext is like FromSlogHandler, but the returned
+// Logger threads ctx through to handler.Handle instead of
+// context.Background(), so trace/span correlation attached to ctx (for
+// example via OpenTelemetry) reaches handler. This only has an effect when
+// handler isn't itself a SlogSink recovered by ToSlogHandler, since those
+// receive their own caller-supplied context directly through Handle.
+func FromSlogHandlerWithContext(ctx context.Context, handler slog.Handler) Logger {
+	logger := FromSlogHandler(handler)
+	if sink, ok := logger.GetSink().(*slogSink); ok {
+		clone := *sink
+		clone.ctx = ctx
+		return logger.WithSink(&clone)
+	}
+	return logger
+}
+
+// slogHandler is a slog.Handler which wraps a LogSink that doesn't
+// otherwise know about slog. If the LogSink also implements SlogSink, the
+// extra work here is limited to level conversion.
+type slogHandler struct {
+	// May be nil, in which case all logs get discarded.
+	sink LogSink
+	// groupPrefix is the accumulated "." separated group name, used only
+	// when sink doesn't implement SlogSink.
+	groupPrefix string
+	// levelBias can be used to shift the logr verbosity level, because
+	// logr levels are all >= 0, while slog levels can be negative.
+	levelBias slog.Level
+}
+
+var _ slog.Handler = &slogHandler{}
+
+// GetLevel exposes the effective level bias, primarily for testing and
+// debugging purposes.
+func (l *slogHandler) GetLevel() slog.Level {
+	return l.levelBias
+}
+
+func (l *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return l.sink != nil && l.sink.Enabled(l.levelFromSlog(level))
+}
+
+func (l *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if l.sink == nil {
+		return nil
+	}
+
+	if sink, ok := l.sink.(SlogSink); ok {
+		if record.Level < slog.LevelError {
+			record.Level -= l.levelBias
+		}
+		return sink.Handle(ctx, record)
+	}
+
+	kvList := make([]any, 0, 2*record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		kvList = attrToKVList(attr, l.groupPrefix, kvList)
+		return true
+	})
+
+	if record.Level >= slog.LevelError {
+		l.sink.Error(nil, record.Message, kvList...)
+	} else {
+		l.sink.Info(l.levelFromSlog(record.Level), record.Message, kvList...)
+	}
+	return nil
+}
+
+func (l *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if l.sink == nil {
+		return l
+	}
+	clone := *l
+	if sink, ok := l.sink.(SlogSink); ok {
+		clone.sink = sink.WithAttrs(attrs)
+	} else {
+		kvList := make([]any, 0, 2*len(attrs))
+		for _, attr := range attrs {
+			kvList = attrToKVList(attr, l.groupPrefix, kvList)
+		}
+		clone.sink = l.sink.WithValues(kvList...)
+	}
+	return &clone
+}
+
+func (l *slogHandler) WithGroup(name string) slog.Handler {
+	if l.sink == nil || name == "" {
+		return l
+	}
+	clone := *l
+	if sink, ok := l.sink.(SlogSink); ok {
+		clone.sink = sink.WithGroup(name)
+	} else {
+		clone.groupPrefix = addPrefix(l.groupPrefix, name)
+	}
+	return &clone
+}
+
+// levelFromSlog converts a slog.Level into a logr verbosity level, biased
+// by levelBias and clamped to a minimum of 0 (logr has no notion of
+// negative verbosity).
+func (l *slogHandler) levelFromSlog(level slog.Level) int {
+	result := -level
+	result += l.levelBias
+	if result < 0 {
+		result = 0
+	}
+	return int(result)
+}
+
+// attrToKVList flattens a slog.Attr into a logr-style key/value list using
+// groupPrefix as a "." separated key prefix for nested groups. A group
+// attr with an empty key is inlined into the parent scope.
+func attrToKVList(attr slog.Attr, groupPrefix string, kvList []any) []any {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		prefix := addPrefix(groupPrefix, attr.Key)
+		for _, groupAttr := range attr.Value.Group() {
+			kvList = attrToKVList(groupAttr, prefix, kvList)
+		}
+		return kvList
+	}
+	if attr.Key == "" {
+		return kvList
+	}
+	return append(kvList, addPrefix(groupPrefix, attr.Key), attr.Value.Any())
+}
+
+func addPrefix(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return fmt.Sprintf("%s.%s", prefix, name)
+}
@@ -0,0 +1,188 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Copyright 2023 The logr Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// SlogSink is an optional interface that a LogSink can implement to support
+// logging through the slog.Logger or slog.Handler APIs better. When a
+// LogSink implements this interface, ToSlogHandler uses it directly instead
+// of converting each Record into logr's key/value calling convention,
+// preserving slog-specific behavior like groups.
+type SlogSink interface {
+	LogSink
+
+	Handle(ctx context.Context, record slog.Record) error
+	WithAttrs(attrs []slog.Attr) SlogSink
+	WithGroup(name string) SlogSink
+}
+
+// slogSink is a LogSink (and SlogSink) which writes to an arbitrary
+// slog.Handler. It's what backs a Logger returned by FromSlogHandler for
+// handlers logr didn't create itself.
+type slogSink struct {
+	callDepth int
+	name      string
+	handler   slog.Handler
+	// ctx, if set (via FromSlogHandlerWithContext), is passed to handler's
+	// Handle instead of context.Background(), so trace/span correlation
+	// attached to it (e.g. via OpenTelemetry) reaches handler. This is the
+	// reverse-path equivalent of funcr's Options.TraceContext.
+	ctx context.Context
+}
+
+var _ LogSink = &slogSink{}
+var _ CallDepthLogSink = &slogSink{}
+var _ SlogSink = &slogSink{}
+
+func (l *slogSink) Init(info RuntimeInfo) {
+	l.callDepth = info.CallDepth
+}
+
+func (l *slogSink) WithCallDepth(depth int) LogSink {
+	clone := *l
+	clone.callDepth += depth
+	return &clone
+}
+
+func (l *slogSink) Enabled(level int) bool {
+	return l.handler.Enabled(context.Background(), slog.Level(-level))
+}
+
+func (l *slogSink) Info(level int, msg string, kvList ...any) {
+	l.log(nil, msg, slog.Level(-level), kvList...)
+}
+
+func (l *slogSink) Error(err error, msg string, kvList ...any) {
+	l.log(err, msg, slog.LevelError, kvList...)
+}
+
+func (l *slogSink) log(err error, msg string, level slog.Level, kvList ...any) {
+	var pcs [1]uintptr
+	// 3 = runtime.Callers, this function, and slogSink.{Info,Error};
+	// callDepth (>= 1) accounts for logr.Logger.{Info,Error} plus any extra
+	// frames added via Logger.WithCallDepth.
+	runtime.Callers(3+l.callDepth, pcs[:])
+
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if err != nil {
+		kvList = append([]any{"err", err}, kvList...)
+	}
+	record.Add(kvList...)
+	if l.name != "" {
+		record.AddAttrs(slog.String("logger", l.name))
+	}
+	ctx := l.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_ = l.handler.Handle(ctx, record)
+}
+
+func (l *slogSink) WithName(name string) LogSink {
+	clone := *l
+	if clone.name != "" {
+		clone.name += "/"
+	}
+	clone.name += name
+	return &clone
+}
+
+func (l *slogSink) WithValues(kvList ...any) LogSink {
+	clone := *l
+	clone.handler = l.handler.WithAttrs(kvListToAttr(kvList))
+	return &clone
+}
+
+func (l *slogSink) Handle(ctx context.Context, record slog.Record) error {
+	return l.handler.Handle(ctx, record)
+}
+
+func (l *slogSink) WithAttrs(attrs []slog.Attr) SlogSink {
+	clone := *l
+	clone.handler = l.handler.WithAttrs(attrs)
+	return &clone
+}
+
+func (l *slogSink) WithGroup(name string) SlogSink {
+	clone := *l
+	clone.handler = l.handler.WithGroup(name)
+	return &clone
+}
+
+func init() {
+	groupContextHook = func(logger Logger, name string) Logger {
+		if sink, ok := logger.GetSink().(SlogSink); ok {
+			return logger.WithSink(sink.WithGroup(name))
+		}
+		return logger
+	}
+}
+
+// FoldAttrsFromContext folds any key/value pairs or groups recorded on ctx
+// via AppendToContext/WithGroupToContext directly into sink, via
+// LogSink.WithValues and, for groups, SlogSink.WithGroup. It's meant for
+// SlogSink implementations (like funcr's) whose Handle method receives ctx
+// directly and so never goes through FromContext to pick up
+// context-carried attributes.
+func FoldAttrsFromContext(ctx context.Context, sink LogSink) LogSink {
+	for _, n := range collectContextNodes(headNodeFromContext(ctx)) {
+		if n.kv != nil {
+			sink = sink.WithValues(n.kv...)
+			continue
+		}
+		if gs, ok := sink.(SlogSink); ok {
+			sink = gs.WithGroup(n.group)
+		}
+	}
+	return sink
+}
+
+// kvListToAttr converts a logr-style alternating key/value list into slog
+// Attrs, coercing non-string keys to strings with fmt and pairing a trailing
+// key that has no value with "(MISSING)". (slog.Logger.With would instead
+// file such entries under "!BADKEY".)
+func kvListToAttr(keysAndValues []any) []slog.Attr {
+	numFields := len(keysAndValues) / 2
+	if len(keysAndValues)%2 != 0 {
+		numFields++
+	}
+	fields := make([]slog.Attr, 0, numFields)
+	for i := 0; i < len(keysAndValues); {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", keysAndValues[i])
+		}
+		if i+1 < len(keysAndValues) {
+			fields = append(fields, slog.Any(key, keysAndValues[i+1]))
+			i += 2
+		} else {
+			fields = append(fields, slog.String(key, "(MISSING)"))
+			i++
+		}
+	}
+	return fields
+}
@@ -106,6 +106,34 @@ func TestWithCallDepth(t *testing.T) {
 	}
 }
 
+type traceCtxKey struct{}
+
+// recordingHandler is a slog.Handler that does nothing but record the
+// context.Context it was called with, for verifying that it's propagated.
+type recordingHandler struct {
+	got *context.Context
+}
+
+func (h recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h recordingHandler) Handle(ctx context.Context, _ slog.Record) error {
+	*h.got = ctx
+	return nil
+}
+func (h recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestFromSlogHandlerWithContext(t *testing.T) {
+	var got context.Context
+	ctx := context.WithValue(context.Background(), traceCtxKey{}, "marker")
+
+	logger := logr.FromSlogHandlerWithContext(ctx, recordingHandler{got: &got})
+	logger.Info("hello")
+
+	if got == nil || got.Value(traceCtxKey{}) != "marker" {
+		t.Errorf("expected the wrapped handler to receive the supplied context, got %v", got)
+	}
+}
+
 func TestJSONHandler(t *testing.T) {
 	testSlog(t, func(buffer *bytes.Buffer) logr.Logger {
 		handler := slog.NewJSONHandler(buffer, nil)
@@ -165,16 +193,7 @@ func TestFuncrHandler(t *testing.T) {
 		}
 		return funcr.NewJSON(printfn, opts)
 	}
-	exceptions := []string{
-		"a Handler should ignore a zero Record.Time",                     // Time is generated by sink.
-		"a Handler should handle Group attributes",                       // funcr doesn't.
-		"a Handler should inline the Attrs of a group with an empty key", // funcr doesn't know about groups.
-		"a Handler should not output groups for an empty Record",         // Relies on WithGroup. Text may change, see https://go.dev/cl/516155
-		"a Handler should handle the WithGroup method",                   // logHandler does by prefixing keys, which is not what the test expects.
-		"a Handler should handle multiple WithGroup and WithAttr calls",  // Same.
-		"a Handler should call Resolve on attribute values in groups",    // funcr doesn't do that and slogHandler can't do it for it.
-	}
-	testSlog(t, fn, exceptions...)
+	testSlog(t, fn)
 }
 
 func testSlog(t *testing.T, createLogger func(buffer *bytes.Buffer) logr.Logger, exceptions ...string) {